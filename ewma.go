@@ -8,14 +8,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sync"
 	"unsafe"
 )
 
-// EWMA represents an Exponential Weighted Moving Average calculator
+// EWMA represents a simple Exponential Weighted Moving Average calculator.
+// It is the original, sample-based MovingAverage implementation in this
+// package; see VariableEWMA, SMA and DEMA for the others.
 type EWMA struct {
-	Alpha  float64 `json:"alpha"`
-	Value  float64 `json:"value"`
-	IsInit bool    `json:"is_init"`
+	mu sync.RWMutex
+
+	alpha  float64
+	value  float64
+	isInit bool
 }
 
 // NewEWMA creates a new EWMA instance with the given alpha parameter
@@ -25,32 +30,73 @@ func NewEWMA(alpha float64) *EWMA {
 		alpha = 0.1 // Default to 0.1 if invalid
 	}
 	return &EWMA{
-		Alpha:  alpha,
-		Value:  0.0,
-		IsInit: false,
+		alpha:  alpha,
+		value:  0.0,
+		isInit: false,
 	}
 }
 
 // Update adds a new observation to the EWMA and returns the updated average
 func (e *EWMA) Update(value float64) float64 {
-	if !e.IsInit {
-		e.Value = value
-		e.IsInit = true
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.isInit {
+		e.value = value
+		e.isInit = true
 	} else {
-		e.Value = e.Alpha*value + (1-e.Alpha)*e.Value
+		e.value = e.alpha*value + (1-e.alpha)*e.value
 	}
-	return e.Value
+	return e.value
+}
+
+// Add records a new observation. It satisfies the MovingAverage interface
+// as a void-returning counterpart to Update.
+func (e *EWMA) Add(value float64) {
+	e.Update(value)
 }
 
 // GetValue returns the current EWMA value
 func (e *EWMA) GetValue() float64 {
-	return e.Value
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.value
+}
+
+// Value returns the current EWMA value. It satisfies the MovingAverage
+// interface as an alias of GetValue.
+func (e *EWMA) Value() float64 {
+	return e.GetValue()
+}
+
+// Set forces the current EWMA value, marking it initialized.
+func (e *EWMA) Set(value float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.value = value
+	e.isInit = true
 }
 
 // Reset resets the EWMA to its initial state
 func (e *EWMA) Reset() {
-	e.Value = 0.0
-	e.IsInit = false
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.value = 0.0
+	e.isInit = false
+}
+
+// GetAlpha returns the current alpha parameter.
+func (e *EWMA) GetAlpha() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.alpha
+}
+
+// IsInitialized reports whether the EWMA has received at least one
+// observation.
+func (e *EWMA) IsInitialized() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isInit
 }
 
 // SetAlpha updates the alpha parameter
@@ -58,7 +104,9 @@ func (e *EWMA) SetAlpha(alpha float64) bool {
 	if alpha < 0 || alpha > 1 {
 		return false
 	}
-	e.Alpha = alpha
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.alpha = alpha
 	return true
 }
 
@@ -71,52 +119,90 @@ func (e *EWMA) CalculateBatch(values []float64) []float64 {
 	return results
 }
 
-// ToJSON serializes the EWMA state to JSON
+type ewmaJSON struct {
+	Kind   string  `json:"kind"`
+	Alpha  float64 `json:"alpha"`
+	Value  float64 `json:"value"`
+	IsInit bool    `json:"is_init"`
+}
+
+// ToJSON serializes the EWMA state to JSON, tagged with its kind.
 func (e *EWMA) ToJSON() string {
-	data, err := json.Marshal(e)
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	data, err := json.Marshal(ewmaJSON{
+		Kind:   kindTagSimpleEWMA,
+		Alpha:  e.alpha,
+		Value:  e.value,
+		IsInit: e.isInit,
+	})
 	if err != nil {
 		return "{\"error\":\"serialization failed\"}"
 	}
 	return string(data)
 }
 
-// FromJSON deserializes EWMA state from JSON
+// FromJSON deserializes EWMA state from JSON, refusing blobs tagged with a
+// different kind.
 func (e *EWMA) FromJSON(jsonStr string) bool {
-	err := json.Unmarshal([]byte(jsonStr), e)
-	return err == nil
+	var parsed ewmaJSON
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || parsed.Kind != kindTagSimpleEWMA {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.alpha = parsed.Alpha
+	e.value = parsed.Value
+	e.isInit = parsed.IsInit
+	return true
 }
 
-// Global EWMA instances for C interface
-var ewmaInstances = make(map[int]*EWMA)
-var nextInstanceID = 1
+// Global MovingAverage instances for C interface, guarded by instancesMu so
+// concurrent Create/Update/Destroy calls from different C threads can't
+// race on the map itself.
+var (
+	instancesMu    sync.RWMutex
+	ewmaInstances  = make(map[int]MovingAverage)
+	nextInstanceID = 1
+)
 
 //export CreateEWMA
-func CreateEWMA(alpha float64) int {
+func CreateEWMA(kind int, param float64) int {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
 	id := nextInstanceID
 	nextInstanceID++
-	ewmaInstances[id] = NewEWMA(alpha)
+	ewmaInstances[id] = newMovingAverage(Kind(kind), param)
 	return id
 }
 
+func getEWMAInstance(instanceID int) (MovingAverage, bool) {
+	instancesMu.RLock()
+	defer instancesMu.RUnlock()
+	ewma, exists := ewmaInstances[instanceID]
+	return ewma, exists
+}
+
 //export UpdateEWMA
 func UpdateEWMA(instanceID int, value float64) float64 {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		return ewma.Update(value)
+	if ewma, exists := getEWMAInstance(instanceID); exists {
+		ewma.Add(value)
+		return ewma.Value()
 	}
 	return math.NaN()
 }
 
 //export GetEWMAValue
 func GetEWMAValue(instanceID int) float64 {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		return ewma.GetValue()
+	if ewma, exists := getEWMAInstance(instanceID); exists {
+		return ewma.Value()
 	}
 	return math.NaN()
 }
 
 //export ResetEWMA
 func ResetEWMA(instanceID int) bool {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
+	if ewma, exists := getEWMAInstance(instanceID); exists {
 		ewma.Reset()
 		return true
 	}
@@ -125,14 +211,21 @@ func ResetEWMA(instanceID int) bool {
 
 //export SetEWMAAlpha
 func SetEWMAAlpha(instanceID int, alpha float64) bool {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		return ewma.SetAlpha(alpha)
+	ewma, exists := getEWMAInstance(instanceID)
+	if !exists {
+		return false
 	}
-	return false
+	setter, ok := ewma.(alphaSetter)
+	if !ok {
+		return false
+	}
+	return setter.SetAlpha(alpha)
 }
 
 //export DestroyEWMA
 func DestroyEWMA(instanceID int) bool {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
 	if _, exists := ewmaInstances[instanceID]; exists {
 		delete(ewmaInstances, instanceID)
 		return true
@@ -141,37 +234,44 @@ func DestroyEWMA(instanceID int) bool {
 }
 
 //export CalculateEWMABatch
-func CalculateEWMABatch(instanceID int, values *float64, length int) *float64 {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		// Convert C array to Go slice
-		valueSlice := (*[1 << 30]float64)(unsafe.Pointer(values))[:length:length]
+func CalculateEWMABatch(instanceID int, in *float64, out *float64, length int) int {
+	ewma, exists := getEWMAInstance(instanceID)
+	if !exists {
+		return -1
+	}
 
-		// Calculate EWMA for all values
-		results := ewma.CalculateBatch(valueSlice)
+	// Convert C arrays to Go slices
+	inSlice := (*[1 << 30]float64)(unsafe.Pointer(in))[:length:length]
+	outSlice := (*[1 << 30]float64)(unsafe.Pointer(out))[:length:length]
 
-		// Convert Go slice to C array
-		if len(results) > 0 {
-			return &results[0]
-		}
+	for i, value := range inSlice {
+		ewma.Add(value)
+		outSlice[i] = ewma.Value()
 	}
-	return nil
+	return len(inSlice)
 }
 
 //export GetEWMAStateJSON
 func GetEWMAStateJSON(instanceID int) *C.char {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		jsonStr := ewma.ToJSON()
-		return C.CString(jsonStr)
+	if ewma, exists := getEWMAInstance(instanceID); exists {
+		if persistable, ok := ewma.(jsonPersistable); ok {
+			return C.CString(persistable.ToJSON())
+		}
 	}
 	return C.CString("{\"error\":\"instance not found\"}")
 }
 
 //export SetEWMAStateJSON
 func SetEWMAStateJSON(instanceID int, jsonStr *C.char) bool {
-	if ewma, exists := ewmaInstances[instanceID]; exists {
-		return ewma.FromJSON(C.GoString(jsonStr))
+	ewma, exists := getEWMAInstance(instanceID)
+	if !exists {
+		return false
 	}
-	return false
+	persistable, ok := ewma.(jsonPersistable)
+	if !ok {
+		return false
+	}
+	return persistable.FromJSON(C.GoString(jsonStr))
 }
 
 //export FreeString
@@ -2,28 +2,29 @@ package main
 
 import (
 	"math"
+	"sync"
 	"testing"
 )
 
 func TestNewEWMA(t *testing.T) {
 	ewma := NewEWMA(0.3)
-	if ewma.Alpha != 0.3 {
-		t.Errorf("Expected alpha 0.3, got %f", ewma.Alpha)
+	if ewma.GetAlpha() != 0.3 {
+		t.Errorf("Expected alpha 0.3, got %f", ewma.GetAlpha())
 	}
-	if ewma.IsInit {
-		t.Error("Expected IsInit to be false")
+	if ewma.IsInitialized() {
+		t.Error("Expected IsInitialized to be false")
 	}
 }
 
 func TestNewEWMAInvalidAlpha(t *testing.T) {
 	ewma := NewEWMA(-0.1)
-	if ewma.Alpha != 0.1 {
-		t.Errorf("Expected default alpha 0.1, got %f", ewma.Alpha)
+	if ewma.GetAlpha() != 0.1 {
+		t.Errorf("Expected default alpha 0.1, got %f", ewma.GetAlpha())
 	}
 
 	ewma2 := NewEWMA(1.5)
-	if ewma2.Alpha != 0.1 {
-		t.Errorf("Expected default alpha 0.1, got %f", ewma2.Alpha)
+	if ewma2.GetAlpha() != 0.1 {
+		t.Errorf("Expected default alpha 0.1, got %f", ewma2.GetAlpha())
 	}
 }
 
@@ -70,11 +71,11 @@ func TestEWMAReset(t *testing.T) {
 	ewma.Update(10.0)
 	ewma.Reset()
 
-	if ewma.IsInit {
-		t.Error("Expected IsInit to be false after reset")
+	if ewma.IsInitialized() {
+		t.Error("Expected IsInitialized to be false after reset")
 	}
-	if ewma.Value != 0.0 {
-		t.Errorf("Expected value 0.0 after reset, got %f", ewma.Value)
+	if ewma.GetValue() != 0.0 {
+		t.Errorf("Expected value 0.0 after reset, got %f", ewma.GetValue())
 	}
 }
 
@@ -85,16 +86,16 @@ func TestEWMASetAlpha(t *testing.T) {
 	if !ewma.SetAlpha(0.5) {
 		t.Error("Expected SetAlpha to return true for valid alpha")
 	}
-	if ewma.Alpha != 0.5 {
-		t.Errorf("Expected alpha 0.5, got %f", ewma.Alpha)
+	if ewma.GetAlpha() != 0.5 {
+		t.Errorf("Expected alpha 0.5, got %f", ewma.GetAlpha())
 	}
 
 	// Invalid alpha
 	if ewma.SetAlpha(-0.1) {
 		t.Error("Expected SetAlpha to return false for invalid alpha")
 	}
-	if ewma.Alpha != 0.5 {
-		t.Errorf("Expected alpha to remain 0.5, got %f", ewma.Alpha)
+	if ewma.GetAlpha() != 0.5 {
+		t.Errorf("Expected alpha to remain 0.5, got %f", ewma.GetAlpha())
 	}
 }
 
@@ -116,14 +117,61 @@ func TestEWMAJSON(t *testing.T) {
 	}
 
 	// Check values
-	if ewma2.Alpha != ewma.Alpha {
-		t.Errorf("Expected alpha %f, got %f", ewma.Alpha, ewma2.Alpha)
+	if ewma2.GetAlpha() != ewma.GetAlpha() {
+		t.Errorf("Expected alpha %f, got %f", ewma.GetAlpha(), ewma2.GetAlpha())
 	}
-	if ewma2.Value != ewma.Value {
-		t.Errorf("Expected value %f, got %f", ewma.Value, ewma2.Value)
+	if ewma2.GetValue() != ewma.GetValue() {
+		t.Errorf("Expected value %f, got %f", ewma.GetValue(), ewma2.GetValue())
 	}
-	if ewma2.IsInit != ewma.IsInit {
-		t.Errorf("Expected IsInit %t, got %t", ewma.IsInit, ewma2.IsInit)
+	if ewma2.IsInitialized() != ewma.IsInitialized() {
+		t.Errorf("Expected IsInitialized %t, got %t", ewma.IsInitialized(), ewma2.IsInitialized())
+	}
+}
+
+func TestEWMAJSONRejectsWrongKind(t *testing.T) {
+	ewma := NewEWMA(0.3)
+	if ewma.FromJSON(`{"kind":"sma","window":[1,2,3],"idx":0,"filled":false}`) {
+		t.Error("Expected FromJSON to reject a blob tagged with a different kind")
+	}
+}
+
+func TestEWMAConcurrentUpdate(t *testing.T) {
+	ewma := NewEWMA(0.3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(value float64) {
+			defer wg.Done()
+			ewma.Update(value)
+		}(float64(i))
+	}
+	wg.Wait()
+
+	if !ewma.IsInitialized() {
+		t.Error("Expected IsInitialized to be true after concurrent updates")
+	}
+	if math.IsNaN(ewma.GetValue()) {
+		t.Error("Expected a numeric value after concurrent updates")
+	}
+}
+
+func TestCalculateEWMABatchCopiesIntoOutputBuffer(t *testing.T) {
+	instanceID := CreateEWMA(int(KindSimpleEWMA), 0.5)
+	in := []float64{10, 20, 15, 25}
+	out := make([]float64, len(in))
+
+	n := CalculateEWMABatch(instanceID, &in[0], &out[0], len(in))
+	if n != len(in) {
+		t.Errorf("Expected %d results, got %d", len(in), n)
+	}
+	if out[0] != 10.0 {
+		t.Errorf("Expected first result 10.0, got %f", out[0])
+	}
+
+	expected := 0.5*20.0 + 0.5*10.0
+	if math.Abs(out[1]-expected) > 1e-10 {
+		t.Errorf("Expected second result %f, got %f", expected, out[1])
 	}
 }
 
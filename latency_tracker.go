@@ -0,0 +1,194 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"math"
+	"sync"
+	"unsafe"
+)
+
+// latencyJitterWeight scales how heavily Score penalizes the gap between a
+// tracker's smoothed RTT and its observed floor: a server whose RTT swings
+// far above its best-case minimum ranks worse than one that is merely slow
+// but steady.
+const latencyJitterWeight = 1.0
+
+// LatencyTracker models the dnscrypt-proxy server-selection use case: it
+// smooths round-trip-time observations with an EWMA and tracks a decaying
+// floor of the best RTTs seen, so that Score can rank servers by both
+// latency and jitter.
+type LatencyTracker struct {
+	mu sync.RWMutex
+
+	smooth     *EWMA
+	floorDecay float64
+	min        float64
+	hasMin     bool
+}
+
+// NewLatencyTracker creates a LatencyTracker. alpha smooths the RTT EWMA;
+// floorDecay controls how quickly the tracked minimum relaxes upward when
+// observations come in above it (0 freezes the floor at its lowest-ever
+// value, 1 makes it track the latest observation).
+func NewLatencyTracker(alpha float64, floorDecay float64) *LatencyTracker {
+	return &LatencyTracker{
+		smooth:     NewEWMA(alpha),
+		floorDecay: floorDecay,
+	}
+}
+
+// Observe records a round-trip-time observation in milliseconds.
+func (l *LatencyTracker) Observe(rttMillis float64) {
+	l.smooth.Update(rttMillis)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.hasMin || rttMillis < l.min {
+		l.min = rttMillis
+		l.hasMin = true
+		return
+	}
+	l.min += l.floorDecay * (rttMillis - l.min)
+}
+
+// SmoothRTT returns the EWMA-smoothed round-trip time.
+func (l *LatencyTracker) SmoothRTT() float64 {
+	return l.smooth.GetValue()
+}
+
+// MinRTT returns the tracked rolling-minimum round-trip time.
+func (l *LatencyTracker) MinRTT() float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.min
+}
+
+// Penalize multiplies the current smoothed RTT by factor, down-ranking a
+// server that just misbehaved (e.g. timed out or returned a bad answer)
+// without waiting for enough observations to drag the EWMA up on its own.
+func (l *LatencyTracker) Penalize(factor float64) {
+	l.smooth.Set(l.smooth.GetValue() * factor)
+}
+
+// Score returns a lower-is-better ranking: the smoothed RTT plus a
+// jitter penalty for how far it sits above the tracked minimum, so a
+// jittery server ranks worse than a steady one with the same average RTT.
+func (l *LatencyTracker) Score() float64 {
+	smooth := l.SmoothRTT()
+	return smooth + latencyJitterWeight*(smooth-l.MinRTT())
+}
+
+// PickBest returns the index of the tracker with the lowest Score, or -1
+// if trackers is empty.
+func PickBest(trackers []*LatencyTracker) int {
+	best := -1
+	bestScore := math.Inf(1)
+	for i, t := range trackers {
+		if t == nil {
+			continue
+		}
+		if score := t.Score(); score < bestScore {
+			bestScore = score
+			best = i
+		}
+	}
+	return best
+}
+
+// Global LatencyTracker instances for C interface, guarded by
+// latencyInstancesMu so concurrent calls from different C threads can't
+// race on the map.
+var (
+	latencyInstancesMu    sync.RWMutex
+	latencyInstances      = make(map[int]*LatencyTracker)
+	nextLatencyInstanceID = 1
+)
+
+//export CreateLatencyTracker
+func CreateLatencyTracker(alpha float64, floorDecay float64) int {
+	latencyInstancesMu.Lock()
+	defer latencyInstancesMu.Unlock()
+	id := nextLatencyInstanceID
+	nextLatencyInstanceID++
+	latencyInstances[id] = NewLatencyTracker(alpha, floorDecay)
+	return id
+}
+
+func getLatencyTracker(instanceID int) (*LatencyTracker, bool) {
+	latencyInstancesMu.RLock()
+	defer latencyInstancesMu.RUnlock()
+	t, exists := latencyInstances[instanceID]
+	return t, exists
+}
+
+//export ObserveLatency
+func ObserveLatency(instanceID int, rttMillis float64) bool {
+	if t, exists := getLatencyTracker(instanceID); exists {
+		t.Observe(rttMillis)
+		return true
+	}
+	return false
+}
+
+//export GetSmoothRTT
+func GetSmoothRTT(instanceID int) float64 {
+	if t, exists := getLatencyTracker(instanceID); exists {
+		return t.SmoothRTT()
+	}
+	return math.NaN()
+}
+
+//export GetMinRTT
+func GetMinRTT(instanceID int) float64 {
+	if t, exists := getLatencyTracker(instanceID); exists {
+		return t.MinRTT()
+	}
+	return math.NaN()
+}
+
+//export PenalizeLatency
+func PenalizeLatency(instanceID int, factor float64) bool {
+	if t, exists := getLatencyTracker(instanceID); exists {
+		t.Penalize(factor)
+		return true
+	}
+	return false
+}
+
+//export GetLatencyScore
+func GetLatencyScore(instanceID int) float64 {
+	if t, exists := getLatencyTracker(instanceID); exists {
+		return t.Score()
+	}
+	return math.NaN()
+}
+
+//export DestroyLatencyTracker
+func DestroyLatencyTracker(instanceID int) bool {
+	latencyInstancesMu.Lock()
+	defer latencyInstancesMu.Unlock()
+	if _, exists := latencyInstances[instanceID]; exists {
+		delete(latencyInstances, instanceID)
+		return true
+	}
+	return false
+}
+
+//export PickBestLatencyTracker
+func PickBestLatencyTracker(instanceIDs *C.int, length int) int {
+	idSlice := (*[1 << 30]C.int)(unsafe.Pointer(instanceIDs))[:length:length]
+
+	trackers := make([]*LatencyTracker, 0, length)
+	for _, id := range idSlice {
+		t, exists := getLatencyTracker(int(id))
+		if !exists {
+			trackers = append(trackers, nil)
+			continue
+		}
+		trackers = append(trackers, t)
+	}
+	return PickBest(trackers)
+}
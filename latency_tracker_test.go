@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLatencyTrackerObserveUpdatesSmoothAndMin(t *testing.T) {
+	lt := NewLatencyTracker(0.5, 0.1)
+	lt.Observe(100)
+	lt.Observe(50)
+
+	if lt.MinRTT() != 50 {
+		t.Errorf("Expected min RTT 50, got %f", lt.MinRTT())
+	}
+	expectedSmooth := 0.5*50 + 0.5*100
+	if math.Abs(lt.SmoothRTT()-expectedSmooth) > 1e-10 {
+		t.Errorf("Expected smooth RTT %f, got %f", expectedSmooth, lt.SmoothRTT())
+	}
+}
+
+func TestLatencyTrackerFloorDecaysUpward(t *testing.T) {
+	lt := NewLatencyTracker(0.5, 0.5)
+	lt.Observe(50)
+	lt.Observe(100) // above min, floor relaxes toward it
+
+	expectedMin := 50.0 + 0.5*(100.0-50.0)
+	if math.Abs(lt.MinRTT()-expectedMin) > 1e-10 {
+		t.Errorf("Expected decayed min %f, got %f", expectedMin, lt.MinRTT())
+	}
+}
+
+func TestLatencyTrackerPenalize(t *testing.T) {
+	lt := NewLatencyTracker(0.5, 0.1)
+	lt.Observe(50)
+	lt.Penalize(2.0)
+
+	expected := 100.0
+	if math.Abs(lt.SmoothRTT()-expected) > 1e-10 {
+		t.Errorf("Expected penalized smooth RTT %f, got %f", expected, lt.SmoothRTT())
+	}
+}
+
+func TestLatencyTrackerScoreRanksJitterWorse(t *testing.T) {
+	steady := NewLatencyTracker(0.5, 0.1)
+	steady.Observe(100)
+	steady.Observe(100)
+
+	jittery := NewLatencyTracker(0.5, 0.1)
+	jittery.Observe(20)
+	jittery.Observe(180)
+
+	if jittery.Score() <= steady.Score() {
+		t.Errorf("Expected jittery tracker (score %f) to rank worse than steady tracker (score %f)", jittery.Score(), steady.Score())
+	}
+}
+
+func TestPickBestReturnsLowestScore(t *testing.T) {
+	good := NewLatencyTracker(0.5, 0.1)
+	good.Observe(10)
+
+	bad := NewLatencyTracker(0.5, 0.1)
+	bad.Observe(500)
+
+	best := PickBest([]*LatencyTracker{bad, good})
+	if best != 1 {
+		t.Errorf("Expected index 1 (the lower-score tracker), got %d", best)
+	}
+}
+
+func TestPickBestEmpty(t *testing.T) {
+	if best := PickBest(nil); best != -1 {
+		t.Errorf("Expected -1 for an empty slice, got %d", best)
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MovingAverage is the common behavior shared by every averaging algorithm
+// in this package, following the shape of the VividCortex/lifenjoiner ewma
+// ecosystem: callers feed observations through Add and read the current
+// estimate through Value, without needing to know which algorithm backs it.
+type MovingAverage interface {
+	Add(value float64)
+	Value() float64
+	Set(value float64)
+	Reset()
+}
+
+// Kind discriminates which MovingAverage algorithm a C-ABI instance or a
+// serialized JSON blob holds.
+type Kind int
+
+const (
+	KindSimpleEWMA Kind = iota
+	KindVariableEWMA
+	KindSMA
+	KindDEMA
+)
+
+const (
+	kindTagSimpleEWMA   = "ewma"
+	kindTagVariableEWMA = "variable_ewma"
+	kindTagSMA          = "sma"
+	kindTagDEMA         = "dema"
+)
+
+// alphaSetter is implemented by MovingAverage algorithms whose smoothing
+// factor can be changed after construction.
+type alphaSetter interface {
+	SetAlpha(alpha float64) bool
+}
+
+// jsonPersistable is implemented by every MovingAverage so its state can be
+// round-tripped across process boundaries via the C ABI.
+type jsonPersistable interface {
+	ToJSON() string
+	FromJSON(jsonStr string) bool
+}
+
+// defaultWarmupSamples is how many samples VariableEWMA averages
+// arithmetically before switching to exponential smoothing.
+const defaultWarmupSamples = 10
+
+// VariableEWMA behaves as a simple arithmetic mean until it has seen
+// warmupSamples observations, then switches to exponential smoothing with
+// alpha = 2/(decay+1). This avoids the cold-start bias of a plain EWMA,
+// where the very first sample pins the whole average.
+type VariableEWMA struct {
+	mu sync.RWMutex
+
+	decay         float64
+	value         float64
+	count         int
+	warmupSamples int
+}
+
+// NewVariableEWMA creates a VariableEWMA with the given decay and the
+// default warmup window.
+func NewVariableEWMA(decay float64) *VariableEWMA {
+	return &VariableEWMA{
+		decay:         decay,
+		warmupSamples: defaultWarmupSamples,
+	}
+}
+
+// Add records a new observation.
+func (v *VariableEWMA) Add(value float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.count < v.warmupSamples {
+		v.count++
+		v.value += (value - v.value) / float64(v.count)
+		return
+	}
+	alpha := 2 / (v.decay + 1)
+	v.value = alpha*value + (1-alpha)*v.value
+}
+
+// Value returns the current estimate.
+func (v *VariableEWMA) Value() float64 {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.value
+}
+
+// Set forces the current estimate, skipping the warmup phase.
+func (v *VariableEWMA) Set(value float64) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.value = value
+	v.count = v.warmupSamples
+}
+
+// Reset returns the VariableEWMA to its cold-start state.
+func (v *VariableEWMA) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.value = 0
+	v.count = 0
+}
+
+// SetAlpha updates the post-warmup decay so it corresponds to the given
+// alpha (alpha = 2/(decay+1)).
+func (v *VariableEWMA) SetAlpha(alpha float64) bool {
+	if alpha <= 0 || alpha > 1 {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.decay = 2/alpha - 1
+	return true
+}
+
+type variableEWMAJSON struct {
+	Kind          string  `json:"kind"`
+	Decay         float64 `json:"decay"`
+	Value         float64 `json:"value"`
+	Count         int     `json:"count"`
+	WarmupSamples int     `json:"warmup_samples"`
+}
+
+// ToJSON serializes the VariableEWMA state to JSON, tagged with its kind.
+func (v *VariableEWMA) ToJSON() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	data, err := json.Marshal(variableEWMAJSON{
+		Kind:          kindTagVariableEWMA,
+		Decay:         v.decay,
+		Value:         v.value,
+		Count:         v.count,
+		WarmupSamples: v.warmupSamples,
+	})
+	if err != nil {
+		return "{\"error\":\"serialization failed\"}"
+	}
+	return string(data)
+}
+
+// FromJSON deserializes VariableEWMA state from JSON, refusing blobs
+// tagged with a different kind.
+func (v *VariableEWMA) FromJSON(jsonStr string) bool {
+	var parsed variableEWMAJSON
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || parsed.Kind != kindTagVariableEWMA {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.decay = parsed.Decay
+	v.value = parsed.Value
+	v.count = parsed.Count
+	v.warmupSamples = parsed.WarmupSamples
+	return true
+}
+
+// defaultSMAWindow is the window size used when SMA is constructed with a
+// non-positive size.
+const defaultSMAWindow = 10
+
+// SMA is a fixed-window simple moving average over the last N observations.
+type SMA struct {
+	mu sync.RWMutex
+
+	window []float64
+	idx    int
+	filled bool
+	sum    float64
+}
+
+// NewSMA creates an SMA over the given window size.
+func NewSMA(size int) *SMA {
+	if size <= 0 {
+		size = defaultSMAWindow
+	}
+	return &SMA{window: make([]float64, size)}
+}
+
+// Add records a new observation, evicting the oldest one once the window
+// is full.
+func (s *SMA) Add(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sum += value - s.window[s.idx]
+	s.window[s.idx] = value
+	s.idx = (s.idx + 1) % len(s.window)
+	if s.idx == 0 {
+		s.filled = true
+	}
+}
+
+// Value returns the average over the observations currently in the window.
+func (s *SMA) Value() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	count := s.idx
+	if s.filled {
+		count = len(s.window)
+	}
+	if count == 0 {
+		return 0
+	}
+	return s.sum / float64(count)
+}
+
+// Set fills the entire window with value.
+func (s *SMA) Set(value float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.window {
+		s.window[i] = value
+	}
+	s.sum = value * float64(len(s.window))
+	s.idx = 0
+	s.filled = true
+}
+
+// Reset empties the window.
+func (s *SMA) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.window {
+		s.window[i] = 0
+	}
+	s.sum = 0
+	s.idx = 0
+	s.filled = false
+}
+
+type smaJSON struct {
+	Kind   string    `json:"kind"`
+	Window []float64 `json:"window"`
+	Idx    int       `json:"idx"`
+	Filled bool      `json:"filled"`
+}
+
+// ToJSON serializes the SMA state to JSON, tagged with its kind.
+func (s *SMA) ToJSON() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := json.Marshal(smaJSON{
+		Kind:   kindTagSMA,
+		Window: append([]float64(nil), s.window...),
+		Idx:    s.idx,
+		Filled: s.filled,
+	})
+	if err != nil {
+		return "{\"error\":\"serialization failed\"}"
+	}
+	return string(data)
+}
+
+// FromJSON deserializes SMA state from JSON, refusing blobs tagged with a
+// different kind.
+func (s *SMA) FromJSON(jsonStr string) bool {
+	var parsed smaJSON
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || parsed.Kind != kindTagSMA {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.window = append([]float64(nil), parsed.Window...)
+	s.idx = parsed.Idx
+	s.filled = parsed.Filled
+	sum := 0.0
+	for _, v := range s.window {
+		sum += v
+	}
+	s.sum = sum
+	return true
+}
+
+// DEMA is a double exponential moving average, computed as 2*ema1 - ema2
+// where ema2 is an EWMA over ema1. It reacts faster to trend changes than
+// a plain EWMA of the same alpha.
+type DEMA struct {
+	mu sync.RWMutex
+
+	alpha  float64
+	ema1   float64
+	ema2   float64
+	isInit bool
+}
+
+// NewDEMA creates a DEMA with the given smoothing factor.
+func NewDEMA(alpha float64) *DEMA {
+	if alpha < 0 || alpha > 1 {
+		alpha = 0.1
+	}
+	return &DEMA{alpha: alpha}
+}
+
+// Add records a new observation.
+func (d *DEMA) Add(value float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.isInit {
+		d.ema1 = value
+		d.ema2 = value
+		d.isInit = true
+		return
+	}
+	d.ema1 = d.alpha*value + (1-d.alpha)*d.ema1
+	d.ema2 = d.alpha*d.ema1 + (1-d.alpha)*d.ema2
+}
+
+// Value returns the current DEMA estimate.
+func (d *DEMA) Value() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return 2*d.ema1 - d.ema2
+}
+
+// Set forces both underlying EMAs to value.
+func (d *DEMA) Set(value float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ema1 = value
+	d.ema2 = value
+	d.isInit = true
+}
+
+// Reset returns the DEMA to its initial state.
+func (d *DEMA) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.ema1 = 0
+	d.ema2 = 0
+	d.isInit = false
+}
+
+// SetAlpha updates the smoothing factor.
+func (d *DEMA) SetAlpha(alpha float64) bool {
+	if alpha < 0 || alpha > 1 {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.alpha = alpha
+	return true
+}
+
+type demaJSON struct {
+	Kind   string  `json:"kind"`
+	Alpha  float64 `json:"alpha"`
+	EMA1   float64 `json:"ema1"`
+	EMA2   float64 `json:"ema2"`
+	IsInit bool    `json:"is_init"`
+}
+
+// ToJSON serializes the DEMA state to JSON, tagged with its kind.
+func (d *DEMA) ToJSON() string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	data, err := json.Marshal(demaJSON{
+		Kind:   kindTagDEMA,
+		Alpha:  d.alpha,
+		EMA1:   d.ema1,
+		EMA2:   d.ema2,
+		IsInit: d.isInit,
+	})
+	if err != nil {
+		return "{\"error\":\"serialization failed\"}"
+	}
+	return string(data)
+}
+
+// FromJSON deserializes DEMA state from JSON, refusing blobs tagged with a
+// different kind.
+func (d *DEMA) FromJSON(jsonStr string) bool {
+	var parsed demaJSON
+	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil || parsed.Kind != kindTagDEMA {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.alpha = parsed.Alpha
+	d.ema1 = parsed.EMA1
+	d.ema2 = parsed.EMA2
+	d.isInit = parsed.IsInit
+	return true
+}
+
+// newMovingAverage constructs the MovingAverage implementation selected by
+// kind. param is interpreted per-kind: alpha for KindSimpleEWMA and
+// KindDEMA, decay for KindVariableEWMA, and window size for KindSMA.
+func newMovingAverage(kind Kind, param float64) MovingAverage {
+	switch kind {
+	case KindVariableEWMA:
+		return NewVariableEWMA(param)
+	case KindSMA:
+		return NewSMA(int(param))
+	case KindDEMA:
+		return NewDEMA(param)
+	default:
+		return NewEWMA(param)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVariableEWMAWarmupIsArithmeticMean(t *testing.T) {
+	v := NewVariableEWMA(0.5)
+	v.Add(10)
+	v.Add(20)
+	v.Add(30)
+
+	expected := (10.0 + 20.0 + 30.0) / 3.0
+	if math.Abs(v.Value()-expected) > 1e-10 {
+		t.Errorf("Expected warmup mean %f, got %f", expected, v.Value())
+	}
+}
+
+func TestVariableEWMASwitchesAfterWarmup(t *testing.T) {
+	v := NewVariableEWMA(9) // alpha = 2/(9+1) = 0.2
+	for i := 0; i < defaultWarmupSamples; i++ {
+		v.Add(10)
+	}
+	before := v.Value()
+
+	v.Add(20)
+	expected := 0.2*20 + 0.8*before
+	if math.Abs(v.Value()-expected) > 1e-10 {
+		t.Errorf("Expected post-warmup EWMA %f, got %f", expected, v.Value())
+	}
+}
+
+func TestVariableEWMAResetAndSet(t *testing.T) {
+	v := NewVariableEWMA(0.5)
+	v.Add(10)
+	v.Set(42)
+	if v.Value() != 42 {
+		t.Errorf("Expected value 42 after Set, got %f", v.Value())
+	}
+
+	v.Reset()
+	if v.Value() != 0 {
+		t.Errorf("Expected value 0 after Reset, got %f", v.Value())
+	}
+}
+
+func TestSMAFixedWindow(t *testing.T) {
+	s := NewSMA(3)
+	s.Add(10)
+	s.Add(20)
+	s.Add(30)
+	if s.Value() != 20 {
+		t.Errorf("Expected average 20, got %f", s.Value())
+	}
+
+	s.Add(60) // evicts the 10
+	expected := (20.0 + 30.0 + 60.0) / 3.0
+	if math.Abs(s.Value()-expected) > 1e-10 {
+		t.Errorf("Expected average %f, got %f", expected, s.Value())
+	}
+}
+
+func TestSMAPartialWindow(t *testing.T) {
+	s := NewSMA(5)
+	s.Add(10)
+	s.Add(20)
+
+	expected := 15.0
+	if math.Abs(s.Value()-expected) > 1e-10 {
+		t.Errorf("Expected partial-window average %f, got %f", expected, s.Value())
+	}
+}
+
+func TestSMAResetAndSet(t *testing.T) {
+	s := NewSMA(3)
+	s.Set(5)
+	if s.Value() != 5 {
+		t.Errorf("Expected value 5 after Set, got %f", s.Value())
+	}
+
+	s.Reset()
+	if s.Value() != 0 {
+		t.Errorf("Expected value 0 after Reset, got %f", s.Value())
+	}
+}
+
+func TestDEMATracksFasterThanEWMA(t *testing.T) {
+	dema := NewDEMA(0.3)
+	ewma := NewEWMA(0.3)
+
+	values := []float64{10, 20, 30, 40, 50}
+	for _, v := range values {
+		dema.Add(v)
+		ewma.Update(v)
+	}
+
+	if dema.Value() <= ewma.GetValue() {
+		t.Errorf("Expected DEMA (%f) to lead a plain EWMA (%f) on a rising trend", dema.Value(), ewma.GetValue())
+	}
+}
+
+func TestDEMAResetAndSet(t *testing.T) {
+	dema := NewDEMA(0.3)
+	dema.Set(7)
+	if dema.Value() != 7 {
+		t.Errorf("Expected value 7 after Set, got %f", dema.Value())
+	}
+
+	dema.Reset()
+	if dema.Value() != 0 {
+		t.Errorf("Expected value 0 after Reset, got %f", dema.Value())
+	}
+}
+
+func TestMovingAverageJSONRoundTrip(t *testing.T) {
+	cases := []MovingAverage{
+		NewEWMA(0.3),
+		NewVariableEWMA(0.5),
+		NewSMA(4),
+		NewDEMA(0.3),
+	}
+
+	for _, ma := range cases {
+		ma.Add(10)
+		ma.Add(20)
+
+		persistable, ok := ma.(jsonPersistable)
+		if !ok {
+			t.Fatalf("%T does not implement jsonPersistable", ma)
+		}
+
+		jsonStr := persistable.ToJSON()
+		restored := newMovingAverage(kindOf(ma), 0).(jsonPersistable)
+		if !restored.FromJSON(jsonStr) {
+			t.Errorf("%T: expected FromJSON to succeed round-tripping %s", ma, jsonStr)
+		}
+	}
+}
+
+// kindOf maps a MovingAverage back to its Kind for round-trip tests.
+func kindOf(ma MovingAverage) Kind {
+	switch ma.(type) {
+	case *VariableEWMA:
+		return KindVariableEWMA
+	case *SMA:
+		return KindSMA
+	case *DEMA:
+		return KindDEMA
+	default:
+		return KindSimpleEWMA
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// EWMASnapshot is an immutable copy of an EWMA's state taken under lock,
+// suitable for safe inspection or serialization after the source EWMA has
+// moved on.
+type EWMASnapshot struct {
+	Name   string  `json:"name"`
+	Alpha  float64 `json:"alpha"`
+	Value  float64 `json:"value"`
+	IsInit bool    `json:"is_init"`
+}
+
+// Registry is a named collection of EWMA series, following the shape of
+// rcrowley/go-metrics as used in go-ethereum: callers look series up by
+// name instead of managing instance IDs themselves, and the whole
+// collection can be snapshotted or scraped at once.
+type Registry struct {
+	mu      sync.RWMutex
+	metrics map[string]*EWMA
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*EWMA)}
+}
+
+// GetOrRegister returns the named EWMA, creating it via factory if this is
+// the first time name has been seen.
+func (r *Registry) GetOrRegister(name string, factory func() *EWMA) *EWMA {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e, exists := r.metrics[name]; exists {
+		return e
+	}
+	e := factory()
+	r.metrics[name] = e
+	return e
+}
+
+// Each calls fn once per registered series. fn must not register new
+// series on this Registry.
+func (r *Registry) Each(fn func(name string, e *EWMA)) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, e := range r.metrics {
+		fn(name, e)
+	}
+}
+
+// Snapshot returns an immutable copy of every registered series' state.
+func (r *Registry) Snapshot() map[string]EWMASnapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := make(map[string]EWMASnapshot, len(r.metrics))
+	for name, e := range r.metrics {
+		e.mu.RLock()
+		snapshot[name] = EWMASnapshot{
+			Name:   name,
+			Alpha:  e.alpha,
+			Value:  e.value,
+			IsInit: e.isInit,
+		}
+		e.mu.RUnlock()
+	}
+	return snapshot
+}
+
+// WritePrometheus writes every registered series to w in the Prometheus
+// text exposition format, sorted by name for a stable scrape output.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	snapshot := r.Snapshot()
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s := snapshot[name]
+		if _, err := fmt.Fprintf(w, "ewma_value{name=%q} %v\n", s.Name, s.Value); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "ewma_alpha{name=%q} %v\n", s.Name, s.Alpha); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler returns an http.Handler that scrapes the Registry in Prometheus
+// text exposition format, for embedders to mount at e.g. /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := r.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Global Registry instances for C interface, guarded by registryInstancesMu
+// so concurrent calls from different C threads can't race on the map.
+var (
+	registryInstancesMu    sync.RWMutex
+	registryInstances      = make(map[int]*Registry)
+	nextRegistryInstanceID = 1
+)
+
+//export RegistryCreate
+func RegistryCreate() int {
+	registryInstancesMu.Lock()
+	defer registryInstancesMu.Unlock()
+	id := nextRegistryInstanceID
+	nextRegistryInstanceID++
+	registryInstances[id] = NewRegistry()
+	return id
+}
+
+func getRegistry(registryID int) (*Registry, bool) {
+	registryInstancesMu.RLock()
+	defer registryInstancesMu.RUnlock()
+	reg, exists := registryInstances[registryID]
+	return reg, exists
+}
+
+// RegistryRegister registers (or looks up) a named EWMA series on the
+// given registry and returns a new ewmaInstances ID bound to it, so the
+// usual UpdateEWMA/GetEWMAValue/... C exports can operate on it directly.
+// Each call allocates a fresh ID, so registering the same name twice yields
+// two IDs that share one series.
+//
+//export RegistryRegister
+func RegistryRegister(registryID int, name *C.char, alpha float64) int {
+	reg, exists := getRegistry(registryID)
+	if !exists {
+		return -1
+	}
+	e := reg.GetOrRegister(C.GoString(name), func() *EWMA { return NewEWMA(alpha) })
+
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	id := nextInstanceID
+	nextInstanceID++
+	ewmaInstances[id] = e
+	return id
+}
+
+//export RegistrySnapshotJSON
+func RegistrySnapshotJSON(registryID int) *C.char {
+	reg, exists := getRegistry(registryID)
+	if !exists {
+		return C.CString("{\"error\":\"registry not found\"}")
+	}
+	data, err := json.Marshal(reg.Snapshot())
+	if err != nil {
+		return C.CString("{\"error\":\"serialization failed\"}")
+	}
+	return C.CString(string(data))
+}
+
+//export DestroyRegistry
+func DestroyRegistry(registryID int) bool {
+	registryInstancesMu.Lock()
+	defer registryInstancesMu.Unlock()
+	if _, exists := registryInstances[registryID]; exists {
+		delete(registryInstances, registryID)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ndjsonRecord is the shape of a non-scalar NDJSON line: {"ts":...,"value":...}.
+// ts is accepted but not used, matching the convention of keeping a
+// timestamp alongside the observation in a log of samples.
+type ndjsonRecord struct {
+	TS    json.Number `json:"ts"`
+	Value float64     `json:"value"`
+}
+
+// parseNDJSONLine accepts either a bare number or an {"ts":...,"value":...}
+// object per line.
+func parseNDJSONLine(line string) (float64, error) {
+	var value float64
+	if err := json.Unmarshal([]byte(line), &value); err == nil {
+		return value, nil
+	}
+
+	var record ndjsonRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return 0, fmt.Errorf("ewma: invalid NDJSON record %q: %w", line, err)
+	}
+	return record.Value, nil
+}
+
+// UpdateFromJSONStream reads one observation per line from r - either a
+// bare number or an {"ts":...,"value":...} object - feeding each through
+// Update, and returns the resulting EWMA series in arrival order.
+func (e *EWMA) UpdateFromJSONStream(r io.Reader) ([]float64, error) {
+	scanner := bufio.NewScanner(r)
+	var results []float64
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		value, err := parseNDJSONLine(line)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, e.Update(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// UpdateFromCSV reads records from r, feeding the value in the given
+// zero-indexed column through Update, and returns the resulting EWMA
+// series in row order.
+func (e *EWMA) UpdateFromCSV(r io.Reader, column int) ([]float64, error) {
+	reader := csv.NewReader(r)
+	var results []float64
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return results, err
+		}
+		if column < 0 || column >= len(record) {
+			return results, fmt.Errorf("ewma: column %d out of range for record %v", column, record)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[column]), 64)
+		if err != nil {
+			return results, fmt.Errorf("ewma: invalid value %q in column %d: %w", record[column], column, err)
+		}
+		results = append(results, e.Update(value))
+	}
+	return results, nil
+}
+
+//export CalculateEWMABatchJSON
+func CalculateEWMABatchJSON(instanceID int, jsonPath *C.char, outJsonPath *C.char) int {
+	ewma, exists := getEWMAInstance(instanceID)
+	if !exists {
+		return -1
+	}
+	e, ok := ewma.(*EWMA)
+	if !ok {
+		return -1
+	}
+
+	f, err := os.Open(C.GoString(jsonPath))
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	results, err := e.UpdateFromJSONStream(f)
+	if err != nil {
+		return -1
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return -1
+	}
+	if err := os.WriteFile(C.GoString(outJsonPath), data, 0644); err != nil {
+		return -1
+	}
+	return len(results)
+}
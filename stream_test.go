@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestUpdateFromJSONStreamBareNumbers(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	input := "10\n20\n15\n"
+
+	results, err := ewma.UpdateFromJSONStream(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0] != 10.0 {
+		t.Errorf("Expected first result 10.0, got %f", results[0])
+	}
+}
+
+func TestUpdateFromJSONStreamObjects(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	input := `{"ts":1,"value":10}
+{"ts":2,"value":20}
+`
+
+	results, err := ewma.UpdateFromJSONStream(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	expected := 0.5*20.0 + 0.5*10.0
+	if math.Abs(results[1]-expected) > 1e-10 {
+		t.Errorf("Expected second result %f, got %f", expected, results[1])
+	}
+}
+
+func TestUpdateFromJSONStreamInvalidLine(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	input := "10\nnot-json\n"
+
+	if _, err := ewma.UpdateFromJSONStream(strings.NewReader(input)); err == nil {
+		t.Error("Expected an error for an invalid NDJSON line")
+	}
+}
+
+func TestUpdateFromCSV(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	input := "1,10\n2,20\n"
+
+	results, err := ewma.UpdateFromCSV(strings.NewReader(input), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	expected := 0.5*20.0 + 0.5*10.0
+	if math.Abs(results[1]-expected) > 1e-10 {
+		t.Errorf("Expected second result %f, got %f", expected, results[1])
+	}
+}
+
+func TestUpdateFromCSVColumnOutOfRange(t *testing.T) {
+	ewma := NewEWMA(0.5)
+	input := "10,20\n"
+
+	if _, err := ewma.UpdateFromCSV(strings.NewReader(input), 5); err == nil {
+		t.Error("Expected an error for an out-of-range column")
+	}
+}
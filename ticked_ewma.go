@@ -0,0 +1,161 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tickedEWMADefaultPeriod is the sampling period used by NewEWMA1, NewEWMA5
+// and NewEWMA15, matching the go-ethereum metrics package convention.
+const tickedEWMADefaultPeriod = 5 * time.Second
+
+// TickedEWMA is an exponentially-weighted moving average of an event rate,
+// driven by wallclock ticks rather than by sample arrival. Events are
+// accumulated between ticks via Update, and Tick folds the instantaneous
+// rate observed over the last sampling period into the smoothed rate.
+type TickedEWMA struct {
+	mu sync.RWMutex
+
+	uncounted int64 // atomically updated; drained by Tick
+	rate      float64
+	alpha     float64
+	init      bool
+	period    time.Duration
+}
+
+// NewTickedEWMA creates a TickedEWMA with the given smoothing factor and
+// sampling period. A non-positive period falls back to the 5s default.
+func NewTickedEWMA(alpha float64, period time.Duration) *TickedEWMA {
+	if period <= 0 {
+		period = tickedEWMADefaultPeriod
+	}
+	return &TickedEWMA{
+		alpha:  alpha,
+		period: period,
+	}
+}
+
+// tickedAlpha returns the smoothing factor for an M-minute moving average
+// sampled every 5 seconds: alpha = 1 - exp(-5/60/M).
+func tickedAlpha(minutes float64) float64 {
+	return 1 - math.Exp(-5.0/60.0/minutes)
+}
+
+// NewEWMA1 creates a TickedEWMA modelling a 1-minute event rate.
+func NewEWMA1() *TickedEWMA {
+	return NewTickedEWMA(tickedAlpha(1), tickedEWMADefaultPeriod)
+}
+
+// NewEWMA5 creates a TickedEWMA modelling a 5-minute event rate.
+func NewEWMA5() *TickedEWMA {
+	return NewTickedEWMA(tickedAlpha(5), tickedEWMADefaultPeriod)
+}
+
+// NewEWMA15 creates a TickedEWMA modelling a 15-minute event rate.
+func NewEWMA15() *TickedEWMA {
+	return NewTickedEWMA(tickedAlpha(15), tickedEWMADefaultPeriod)
+}
+
+// Update records n events since the last tick.
+func (t *TickedEWMA) Update(n int64) {
+	atomic.AddInt64(&t.uncounted, n)
+}
+
+// Tick drains the uncounted event count, computes the instantaneous rate
+// over the sampling period, and folds it into the smoothed rate.
+func (t *TickedEWMA) Tick() {
+	uncounted := atomic.SwapInt64(&t.uncounted, 0)
+	instantRate := float64(uncounted) / t.period.Seconds()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.init {
+		t.rate += t.alpha * (instantRate - t.rate)
+	} else {
+		t.rate = instantRate
+		t.init = true
+	}
+}
+
+// Rate returns the current smoothed events-per-second rate.
+func (t *TickedEWMA) Rate() float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.rate
+}
+
+// StartTicker drives Tick on the TickedEWMA's own sampling period in a
+// background goroutine until ctx is cancelled.
+func (t *TickedEWMA) StartTicker(ctx context.Context) {
+	ticker := time.NewTicker(t.period)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.Tick()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Global TickedEWMA instances for C interface, guarded by tickedInstancesMu
+// so concurrent calls from different C threads can't race on the map.
+var (
+	tickedInstancesMu    sync.RWMutex
+	tickedEWMAInstances  = make(map[int]*TickedEWMA)
+	nextTickedInstanceID = 1
+)
+
+//export CreateTickedEWMA
+func CreateTickedEWMA(alpha float64) int {
+	tickedInstancesMu.Lock()
+	defer tickedInstancesMu.Unlock()
+	id := nextTickedInstanceID
+	nextTickedInstanceID++
+	tickedEWMAInstances[id] = NewTickedEWMA(alpha, tickedEWMADefaultPeriod)
+	return id
+}
+
+func getTickedEWMAInstance(instanceID int) (*TickedEWMA, bool) {
+	tickedInstancesMu.RLock()
+	defer tickedInstancesMu.RUnlock()
+	t, exists := tickedEWMAInstances[instanceID]
+	return t, exists
+}
+
+//export UpdateTickedEWMA
+func UpdateTickedEWMA(instanceID int, n int64) bool {
+	if t, exists := getTickedEWMAInstance(instanceID); exists {
+		t.Update(n)
+		return true
+	}
+	return false
+}
+
+//export TickEWMA
+func TickEWMA(instanceID int) bool {
+	if t, exists := getTickedEWMAInstance(instanceID); exists {
+		t.Tick()
+		return true
+	}
+	return false
+}
+
+//export GetTickedRate
+func GetTickedRate(instanceID int) float64 {
+	if t, exists := getTickedEWMAInstance(instanceID); exists {
+		return t.Rate()
+	}
+	return math.NaN()
+}
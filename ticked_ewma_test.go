@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewTickedEWMADefaultPeriod(t *testing.T) {
+	ticked := NewTickedEWMA(0.5, 0)
+	if ticked.period != tickedEWMADefaultPeriod {
+		t.Errorf("Expected default period %v, got %v", tickedEWMADefaultPeriod, ticked.period)
+	}
+}
+
+func TestNewEWMAPresetsAlpha(t *testing.T) {
+	cases := []struct {
+		name    string
+		ticked  *TickedEWMA
+		minutes float64
+	}{
+		{"EWMA1", NewEWMA1(), 1},
+		{"EWMA5", NewEWMA5(), 5},
+		{"EWMA15", NewEWMA15(), 15},
+	}
+
+	for _, c := range cases {
+		expected := tickedAlpha(c.minutes)
+		if math.Abs(c.ticked.alpha-expected) > 1e-12 {
+			t.Errorf("%s: expected alpha %f, got %f", c.name, expected, c.ticked.alpha)
+		}
+		if c.ticked.period != tickedEWMADefaultPeriod {
+			t.Errorf("%s: expected default period, got %v", c.name, c.ticked.period)
+		}
+	}
+}
+
+func TestTickedEWMAFirstTickSetsRate(t *testing.T) {
+	ticked := NewTickedEWMA(0.5, 5*time.Second)
+	ticked.Update(50)
+	ticked.Tick()
+
+	expected := 50.0 / 5.0
+	if math.Abs(ticked.Rate()-expected) > 1e-10 {
+		t.Errorf("Expected rate %f, got %f", expected, ticked.Rate())
+	}
+}
+
+func TestTickedEWMASubsequentTickSmooths(t *testing.T) {
+	ticked := NewTickedEWMA(0.5, 5*time.Second)
+	ticked.Update(50)
+	ticked.Tick() // rate = 10
+
+	ticked.Update(100)
+	ticked.Tick() // instantRate = 20, rate = 0.5*20 + 0.5*10 = 15
+
+	expected := 15.0
+	if math.Abs(ticked.Rate()-expected) > 1e-10 {
+		t.Errorf("Expected rate %f, got %f", expected, ticked.Rate())
+	}
+}
+
+func TestTickedEWMAUpdateIsCumulativeBetweenTicks(t *testing.T) {
+	ticked := NewTickedEWMA(1, 5*time.Second)
+	ticked.Update(10)
+	ticked.Update(15)
+	ticked.Tick()
+
+	expected := 25.0 / 5.0
+	if math.Abs(ticked.Rate()-expected) > 1e-10 {
+		t.Errorf("Expected rate %f, got %f", expected, ticked.Rate())
+	}
+}
+
+func TestTickedEWMANoEventsTicksToZero(t *testing.T) {
+	ticked := NewTickedEWMA(0.5, 5*time.Second)
+	ticked.Tick()
+
+	if ticked.Rate() != 0 {
+		t.Errorf("Expected rate 0 with no events, got %f", ticked.Rate())
+	}
+}
+
+func TestTickedEWMAStartTicker(t *testing.T) {
+	// alpha < 1 so a subsequent zero-event tick decays the rate instead of
+	// snapping it straight back to 0, which would make this test racy
+	// against however many ticks fire during the sleep below.
+	ticked := NewTickedEWMA(0.5, 10*time.Millisecond)
+	ticked.Update(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ticked.StartTicker(ctx)
+
+	time.Sleep(30 * time.Millisecond)
+	if ticked.Rate() == 0 {
+		t.Error("Expected StartTicker to have driven at least one Tick")
+	}
+}